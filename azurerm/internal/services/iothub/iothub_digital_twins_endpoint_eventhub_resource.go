@@ -0,0 +1,193 @@
+package iothub
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/digitaltwins/mgmt/2020-10-31/digitaltwins"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/azure"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/tf"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/clients"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/services/iothub/parse"
+	azSchema "github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/tf/schema"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/timeouts"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/utils"
+)
+
+func resourceArmDigitalTwinsEndpointEventHub() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceArmDigitalTwinsEndpointEventHubCreate,
+		Read:   resourceArmDigitalTwinsEndpointEventHubRead,
+		Delete: resourceArmDigitalTwinsEndpointEventHubDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(30 * time.Minute),
+		},
+
+		Importer: azSchema.ValidateResourceIDPriorToImport(func(id string) error {
+			_, err := parse.DigitalTwinsEndpointID(id)
+			return err
+		}),
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			"digital_twins_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: azure.ValidateResourceID,
+			},
+
+			"eventhub_primary_connection_string": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Sensitive:    true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			"eventhub_secondary_connection_string": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Sensitive:    true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			"dead_letter_storage_secret": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Sensitive:    true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceArmDigitalTwinsEndpointEventHubCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).IoTHub.DigitalTwinsEndpointClient
+	ctx, cancel := timeouts.ForCreate(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	digitalTwinsId, err := parse.DigitalTwinsID(d.Get("digital_twins_id").(string))
+	if err != nil {
+		return err
+	}
+	name := d.Get("name").(string)
+
+	existing, err := client.Get(ctx, digitalTwinsId.ResourceGroup, digitalTwinsId.Name, name)
+	if err != nil {
+		if !utils.ResponseWasNotFound(existing.Response) {
+			return fmt.Errorf("checking for presence of existing Digital Twins Event Hub Endpoint %q (Digital Twins %q / Resource Group %q): %+v", name, digitalTwinsId.Name, digitalTwinsId.ResourceGroup, err)
+		}
+	}
+	if existing.ID != nil && *existing.ID != "" {
+		return tf.ImportAsExistsError("azurerm_digital_twins_endpoint_eventhub", *existing.ID)
+	}
+
+	properties := digitaltwins.EndpointResource{
+		Properties: &digitaltwins.EventHub{
+			EndpointType:                 digitaltwins.EndpointTypeEventHub,
+			ConnectionStringPrimaryKey:   utils.String(d.Get("eventhub_primary_connection_string").(string)),
+			ConnectionStringSecondaryKey: utils.String(d.Get("eventhub_secondary_connection_string").(string)),
+			DeadLetterSecret:             utils.String(d.Get("dead_letter_storage_secret").(string)),
+		},
+	}
+
+	future, err := client.CreateOrUpdate(ctx, digitalTwinsId.ResourceGroup, digitalTwinsId.Name, name, properties)
+	if err != nil {
+		return fmt.Errorf("creating Digital Twins Event Hub Endpoint %q (Digital Twins %q / Resource Group %q): %+v", name, digitalTwinsId.Name, digitalTwinsId.ResourceGroup, err)
+	}
+
+	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
+		return fmt.Errorf("waiting on creating future for Digital Twins Event Hub Endpoint %q (Digital Twins %q / Resource Group %q): %+v", name, digitalTwinsId.Name, digitalTwinsId.ResourceGroup, err)
+	}
+
+	resp, err := client.Get(ctx, digitalTwinsId.ResourceGroup, digitalTwinsId.Name, name)
+	if err != nil {
+		return fmt.Errorf("retrieving Digital Twins Event Hub Endpoint %q (Digital Twins %q / Resource Group %q): %+v", name, digitalTwinsId.Name, digitalTwinsId.ResourceGroup, err)
+	}
+
+	if resp.ID == nil || *resp.ID == "" {
+		return fmt.Errorf("empty or nil ID returned for Digital Twins Event Hub Endpoint %q (Digital Twins %q / Resource Group %q) ID", name, digitalTwinsId.Name, digitalTwinsId.ResourceGroup)
+	}
+
+	id, err := parse.DigitalTwinsEndpointID(*resp.ID)
+	if err != nil {
+		return err
+	}
+	d.SetId(id.ID(meta.(*clients.Client).Account.SubscriptionId))
+
+	return resourceArmDigitalTwinsEndpointEventHubRead(d, meta)
+}
+
+func resourceArmDigitalTwinsEndpointEventHubRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).IoTHub.DigitalTwinsEndpointClient
+	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.DigitalTwinsEndpointID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Get(ctx, id.ResourceGroup, id.DigitalTwinsName, id.Name)
+	if err != nil {
+		if utils.ResponseWasNotFound(resp.Response) {
+			log.Printf("[INFO] digital twins event hub endpoint %q does not exist - removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("retrieving Digital Twins Event Hub Endpoint %q (Digital Twins %q / Resource Group %q): %+v", id.Name, id.DigitalTwinsName, id.ResourceGroup, err)
+	}
+
+	d.Set("name", id.Name)
+
+	digitalTwinsId := parse.DigitalTwinsId{ResourceGroup: id.ResourceGroup, Name: id.DigitalTwinsName}
+	d.Set("digital_twins_id", digitalTwinsId.ID(meta.(*clients.Client).Account.SubscriptionId))
+
+	if props, ok := resp.Properties.AsEventHub(); ok && props != nil {
+		d.Set("state", string(props.ProvisioningState))
+	}
+
+	return nil
+}
+
+func resourceArmDigitalTwinsEndpointEventHubDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).IoTHub.DigitalTwinsEndpointClient
+	ctx, cancel := timeouts.ForDelete(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.DigitalTwinsEndpointID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	future, err := client.Delete(ctx, id.ResourceGroup, id.DigitalTwinsName, id.Name)
+	if err != nil {
+		return fmt.Errorf("deleting Digital Twins Event Hub Endpoint %q (Digital Twins %q / Resource Group %q): %+v", id.Name, id.DigitalTwinsName, id.ResourceGroup, err)
+	}
+
+	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
+		return fmt.Errorf("waiting on deleting future for Digital Twins Event Hub Endpoint %q (Digital Twins %q / Resource Group %q): %+v", id.Name, id.DigitalTwinsName, id.ResourceGroup, err)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/digitaltwins/mgmt/2020-10-31/digitaltwins"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/azure"
 	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/tf"
 	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/clients"
@@ -55,6 +56,37 @@ func resourceArmDigitalTwins() *schema.Resource {
 				Computed: true,
 			},
 
+			// the 2020-10-31 digitaltwins API only supports a SystemAssigned
+			// identity - UserAssigned would need an API version bump. `type`
+			// accepts only `SystemAssigned`; `SystemAssigned,UserAssigned` and
+			// `identity_ids` are not supported.
+			"identity": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:     schema.TypeString,
+							Required: true,
+							ValidateFunc: validation.StringInSlice([]string{
+								string(digitaltwins.SystemAssigned),
+							}, false),
+						},
+
+						"principal_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"tenant_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
 			"tags": tags.Schema(),
 		},
 	}
@@ -78,8 +110,14 @@ func resourceArmDigitalTwinsCreate(d *schema.ResourceData, meta interface{}) err
 		return tf.ImportAsExistsError("azurerm_iothub_digital_twins", *existing.ID)
 	}
 
+	identity, err := expandDigitalTwinsIdentity(d.Get("identity").([]interface{}))
+	if err != nil {
+		return fmt.Errorf("expanding `identity`: %+v", err)
+	}
+
 	properties := digitaltwins.Description{
 		Location: utils.String(location.Normalize(d.Get("location").(string))),
+		Identity: identity,
 		Tags:     tags.Expand(d.Get("tags").(map[string]interface{})),
 	}
 
@@ -135,6 +173,11 @@ func resourceArmDigitalTwinsRead(d *schema.ResourceData, meta interface{}) error
 	if props := resp.Properties; props != nil {
 		d.Set("host_name", props.HostName)
 	}
+
+	if err := d.Set("identity", flattenDigitalTwinsIdentity(resp.Identity)); err != nil {
+		return fmt.Errorf("setting `identity`: %+v", err)
+	}
+
 	return tags.FlattenAndSet(d, resp.Tags)
 }
 
@@ -150,6 +193,14 @@ func resourceArmDigitalTwinsUpdate(d *schema.ResourceData, meta interface{}) err
 
 	properties := digitaltwins.PatchDescription{}
 
+	if d.HasChange("identity") {
+		identity, err := expandDigitalTwinsIdentity(d.Get("identity").([]interface{}))
+		if err != nil {
+			return fmt.Errorf("expanding `identity`: %+v", err)
+		}
+		properties.Identity = identity
+	}
+
 	if d.HasChange("tags") {
 		properties.Tags = tags.Expand(d.Get("tags").(map[string]interface{}))
 	}
@@ -181,3 +232,41 @@ func resourceArmDigitalTwinsDelete(d *schema.ResourceData, meta interface{}) err
 	}
 	return nil
 }
+
+func expandDigitalTwinsIdentity(input []interface{}) (*digitaltwins.Identity, error) {
+	if len(input) == 0 || input[0] == nil {
+		return &digitaltwins.Identity{
+			Type: digitaltwins.None,
+		}, nil
+	}
+
+	v := input[0].(map[string]interface{})
+
+	return &digitaltwins.Identity{
+		Type: digitaltwins.ResourceIdentityType(v["type"].(string)),
+	}, nil
+}
+
+func flattenDigitalTwinsIdentity(input *digitaltwins.Identity) []interface{} {
+	if input == nil || input.Type == digitaltwins.None {
+		return []interface{}{}
+	}
+
+	principalId := ""
+	if input.PrincipalID != nil {
+		principalId = *input.PrincipalID
+	}
+
+	tenantId := ""
+	if input.TenantID != nil {
+		tenantId = *input.TenantID
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"type":         string(input.Type),
+			"principal_id": principalId,
+			"tenant_id":    tenantId,
+		},
+	}
+}
@@ -0,0 +1,494 @@
+package iothub
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/devices/mgmt/2021-07-02/devices"
+	"github.com/Azure/azure-sdk-for-go/services/digitaltwins/mgmt/2020-10-31/digitaltwins"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/azure"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/tf"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/clients"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/locks"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/services/iothub/parse"
+	azSchema "github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/tf/schema"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/internal/timeouts"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/utils"
+)
+
+const iotHubResourceName = "azurerm_iothub"
+
+// resourceArmIotHubDigitalTwinsRoute wires device telemetry flowing through an
+// `azurerm_iothub` custom endpoint + route into an `azurerm_iothub_digital_twins`
+// instance's event route. It creates the IoT Hub custom endpoint, the IoT Hub
+// route and the Digital Twins event route; it does not create the Digital
+// Twins endpoint named by `digital_twins_endpoint_name`, which must already exist.
+func resourceArmIotHubDigitalTwinsRoute() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceArmIotHubDigitalTwinsRouteCreate,
+		Read:   resourceArmIotHubDigitalTwinsRouteRead,
+		Delete: resourceArmIotHubDigitalTwinsRouteDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(30 * time.Minute),
+		},
+
+		Importer: azSchema.ValidateResourceIDPriorToImport(func(id string) error {
+			_, err := parse.IotHubDigitalTwinsRouteID(id)
+			return err
+		}),
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			"iothub_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: azure.ValidateResourceID,
+			},
+
+			"digital_twins_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: azure.ValidateResourceID,
+			},
+
+			// the azurerm_digital_twins_endpoint_eventhub / _servicebus /
+			// _eventgrid that the Digital Twins event route reads from. This
+			// is a Digital Twins instance endpoint, not an IoT Hub one - see
+			// `iothub_endpoint_name` for the IoT Hub side of the pipeline.
+			"digital_twins_endpoint_name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			// the custom endpoint this resource creates under the IoT Hub's
+			// own `Routing.Endpoints`, which the IoT Hub route forwards
+			// device telemetry into. A route may only reference a built-in
+			// endpoint or one registered here - it cannot reference a
+			// Digital Twins endpoint directly.
+			"iothub_endpoint_name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			"iothub_endpoint_type": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					"EventHub",
+					"ServiceBusQueue",
+					"ServiceBusTopic",
+				}, false),
+			},
+
+			"iothub_endpoint_connection_string": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Sensitive:    true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			// changing the filter on an existing event route would need a
+			// Digital Twins event route Update, which this resource doesn't
+			// implement yet - force a new bridge instead.
+			"filter": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+		},
+	}
+}
+
+func resourceArmIotHubDigitalTwinsRouteCreate(d *schema.ResourceData, meta interface{}) error {
+	iotHubClient := meta.(*clients.Client).IoTHub.ResourceClient
+	eventRoutesClient := meta.(*clients.Client).IoTHub.DigitalTwinsEventRoutesClient
+	ctx, cancel := timeouts.ForCreate(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	iotHubId, err := parse.IotHubID(d.Get("iothub_id").(string))
+	if err != nil {
+		return err
+	}
+
+	digitalTwinsId, err := parse.DigitalTwinsID(d.Get("digital_twins_id").(string))
+	if err != nil {
+		return err
+	}
+
+	name := d.Get("name").(string)
+	digitalTwinsEndpointName := d.Get("digital_twins_endpoint_name").(string)
+	iotHubEndpointName := d.Get("iothub_endpoint_name").(string)
+	iotHubEndpointType := d.Get("iothub_endpoint_type").(string)
+	iotHubEndpointConnectionString := d.Get("iothub_endpoint_connection_string").(string)
+	filter := d.Get("filter").(string)
+
+	existing, err := eventRoutesClient.Get(ctx, digitalTwinsId.ResourceGroup, digitalTwinsId.Name, name)
+	if err != nil {
+		if !utils.ResponseWasNotFound(existing.Response) {
+			return fmt.Errorf("checking for presence of existing IoT Hub Digital Twins Route %q (Digital Twins %q / Resource Group %q): %+v", name, digitalTwinsId.Name, digitalTwinsId.ResourceGroup, err)
+		}
+	}
+	if existing.ID != nil && *existing.ID != "" {
+		return tf.ImportAsExistsError("azurerm_iothub_digital_twins_route", *existing.ID)
+	}
+
+	locks.ByName(iotHubId.Name, iotHubResourceName)
+	defer locks.UnlockByName(iotHubId.Name, iotHubResourceName)
+
+	// step 1: register a custom endpoint on the IoT Hub and point a route at
+	// it, so device telemetry lands in the intermediate Event Hub/Service Bus
+	// sink that the Digital Twins endpoint also consumes from.
+	hub, err := iotHubClient.Get(ctx, iotHubId.ResourceGroup, iotHubId.Name)
+	if err != nil {
+		return fmt.Errorf("retrieving IoT Hub %q (Resource Group %q): %+v", iotHubId.Name, iotHubId.ResourceGroup, err)
+	}
+	if hub.Properties == nil {
+		hub.Properties = &devices.IotHubProperties{}
+	}
+	if hub.Properties.Routing == nil {
+		hub.Properties.Routing = &devices.RoutingProperties{}
+	}
+	if hub.Properties.Routing.Endpoints == nil {
+		hub.Properties.Routing.Endpoints = &devices.RoutingEndpoints{}
+	}
+	if hub.Properties.Routing.Routes == nil {
+		hub.Properties.Routing.Routes = &[]devices.RouteProperties{}
+	}
+
+	if routingEndpointNameExists(hub.Properties.Routing.Endpoints, iotHubEndpointName) {
+		return fmt.Errorf("an endpoint named %q already exists on IoT Hub %q (Resource Group %q)", iotHubEndpointName, iotHubId.Name, iotHubId.ResourceGroup)
+	}
+	for _, route := range *hub.Properties.Routing.Routes {
+		if route.Name != nil && *route.Name == name {
+			return fmt.Errorf("a route named %q already exists on IoT Hub %q (Resource Group %q)", name, iotHubId.Name, iotHubId.ResourceGroup)
+		}
+	}
+
+	addRoutingEndpoint(hub.Properties.Routing.Endpoints, iotHubEndpointType, iotHubEndpointName, iotHubEndpointConnectionString)
+
+	routes := append(*hub.Properties.Routing.Routes, devices.RouteProperties{
+		Name:          utils.String(name),
+		Source:        devices.RoutingSourceDeviceMessages,
+		EndpointNames: &[]string{iotHubEndpointName},
+		Condition:     utils.String("true"),
+		IsEnabled:     utils.Bool(true),
+	})
+	hub.Properties.Routing.Routes = &routes
+
+	ifMatch := ""
+	if hub.Etag != nil {
+		ifMatch = *hub.Etag
+	}
+
+	future, err := iotHubClient.CreateOrUpdate(ctx, iotHubId.ResourceGroup, iotHubId.Name, hub, ifMatch)
+	if err != nil {
+		return fmt.Errorf("adding endpoint %q and route %q to IoT Hub %q (Resource Group %q): %+v", iotHubEndpointName, name, iotHubId.Name, iotHubId.ResourceGroup, err)
+	}
+	if err := future.WaitForCompletionRef(ctx, iotHubClient.Client); err != nil {
+		return fmt.Errorf("waiting on adding endpoint %q and route %q to IoT Hub %q (Resource Group %q): %+v", iotHubEndpointName, name, iotHubId.Name, iotHubId.ResourceGroup, err)
+	}
+
+	// step 2: create the Digital Twins event route that reads from the
+	// Digital Twins endpoint and pushes matching events into twin updates.
+	// If this fails roll back the IoT Hub endpoint/route added above so we
+	// don't leave an orphaned route with nothing consuming its output.
+	eventRoute := digitaltwins.EventRoute{
+		EndpointName: utils.String(digitalTwinsEndpointName),
+		Filter:       utils.String(filter),
+	}
+
+	if _, err := eventRoutesClient.Add(ctx, digitalTwinsId.ResourceGroup, digitalTwinsId.Name, name, eventRoute); err != nil {
+		if rollbackErr := removeIotHubRouteAndEndpoint(ctx, iotHubClient, *iotHubId, name, iotHubEndpointName); rollbackErr != nil {
+			log.Printf("[WARN] failed to roll back IoT Hub route %q / endpoint %q (Resource Group %q) after Digital Twins event route creation failed: %+v", name, iotHubEndpointName, iotHubId.ResourceGroup, rollbackErr)
+		}
+		return fmt.Errorf("creating Digital Twins Event Route %q (Digital Twins %q / Resource Group %q): %+v", name, digitalTwinsId.Name, digitalTwinsId.ResourceGroup, err)
+	}
+
+	subscriptionId := meta.(*clients.Client).Account.SubscriptionId
+	id := parse.IotHubDigitalTwinsRouteId{
+		DigitalTwinsRoute: parse.DigitalTwinsRouteId{
+			ResourceGroup:    digitalTwinsId.ResourceGroup,
+			DigitalTwinsName: digitalTwinsId.Name,
+			Name:             name,
+		},
+		IotHubId: iotHubId.ID(subscriptionId),
+	}
+	d.SetId(id.ID(subscriptionId))
+
+	return resourceArmIotHubDigitalTwinsRouteRead(d, meta)
+}
+
+func resourceArmIotHubDigitalTwinsRouteRead(d *schema.ResourceData, meta interface{}) error {
+	eventRoutesClient := meta.(*clients.Client).IoTHub.DigitalTwinsEventRoutesClient
+	iotHubClient := meta.(*clients.Client).IoTHub.ResourceClient
+	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.IotHubDigitalTwinsRouteID(d.Id())
+	if err != nil {
+		return err
+	}
+	routeId := id.DigitalTwinsRoute
+
+	resp, err := eventRoutesClient.Get(ctx, routeId.ResourceGroup, routeId.DigitalTwinsName, routeId.Name)
+	if err != nil {
+		if utils.ResponseWasNotFound(resp.Response) {
+			log.Printf("[INFO] digital twins event route %q does not exist - removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("retrieving Digital Twins Event Route %q (Digital Twins %q / Resource Group %q): %+v", routeId.Name, routeId.DigitalTwinsName, routeId.ResourceGroup, err)
+	}
+
+	d.Set("name", routeId.Name)
+	d.Set("iothub_id", id.IotHubId)
+
+	digitalTwinsId := parse.DigitalTwinsId{ResourceGroup: routeId.ResourceGroup, Name: routeId.DigitalTwinsName}
+	d.Set("digital_twins_id", digitalTwinsId.ID(meta.(*clients.Client).Account.SubscriptionId))
+
+	if resp.EndpointName != nil {
+		d.Set("digital_twins_endpoint_name", resp.EndpointName)
+	}
+	if resp.Filter != nil {
+		d.Set("filter", resp.Filter)
+	}
+
+	iotHubId, err := parse.IotHubID(id.IotHubId)
+	if err != nil {
+		return err
+	}
+
+	hub, err := iotHubClient.Get(ctx, iotHubId.ResourceGroup, iotHubId.Name)
+	if err != nil {
+		if utils.ResponseWasNotFound(hub.Response) {
+			log.Printf("[INFO] IoT Hub %q (Resource Group %q) does not exist - removing from state", iotHubId.Name, iotHubId.ResourceGroup)
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("retrieving IoT Hub %q (Resource Group %q): %+v", iotHubId.Name, iotHubId.ResourceGroup, err)
+	}
+
+	iotHubEndpointName := findRoutingEndpointName(hub.Properties, routeId.Name)
+	if iotHubEndpointName == "" {
+		log.Printf("[INFO] route %q no longer exists on IoT Hub %q (Resource Group %q) - removing from state", routeId.Name, iotHubId.Name, iotHubId.ResourceGroup)
+		d.SetId("")
+		return nil
+	}
+	d.Set("iothub_endpoint_name", iotHubEndpointName)
+
+	if endpointType, ok := routingEndpointType(hub.Properties.Routing.Endpoints, iotHubEndpointName); ok {
+		d.Set("iothub_endpoint_type", endpointType)
+	}
+
+	return nil
+}
+
+func resourceArmIotHubDigitalTwinsRouteDelete(d *schema.ResourceData, meta interface{}) error {
+	eventRoutesClient := meta.(*clients.Client).IoTHub.DigitalTwinsEventRoutesClient
+	iotHubClient := meta.(*clients.Client).IoTHub.ResourceClient
+	ctx, cancel := timeouts.ForDelete(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.IotHubDigitalTwinsRouteID(d.Id())
+	if err != nil {
+		return err
+	}
+	routeId := id.DigitalTwinsRoute
+
+	iotHubId, err := parse.IotHubID(id.IotHubId)
+	if err != nil {
+		return err
+	}
+
+	iotHubEndpointName := d.Get("iothub_endpoint_name").(string)
+
+	locks.ByName(iotHubId.Name, iotHubResourceName)
+	defer locks.UnlockByName(iotHubId.Name, iotHubResourceName)
+
+	// tear down in the reverse order telemetry flows: stop the twin update
+	// first, then stop the IoT Hub from routing into the now-unused endpoint.
+	if _, err := eventRoutesClient.Delete(ctx, routeId.ResourceGroup, routeId.DigitalTwinsName, routeId.Name); err != nil {
+		return fmt.Errorf("deleting Digital Twins Event Route %q (Digital Twins %q / Resource Group %q): %+v", routeId.Name, routeId.DigitalTwinsName, routeId.ResourceGroup, err)
+	}
+
+	if err := removeIotHubRouteAndEndpoint(ctx, iotHubClient, *iotHubId, routeId.Name, iotHubEndpointName); err != nil {
+		return fmt.Errorf("removing route %q and endpoint %q from IoT Hub %q (Resource Group %q): %+v", routeId.Name, iotHubEndpointName, iotHubId.Name, iotHubId.ResourceGroup, err)
+	}
+
+	return nil
+}
+
+// removeIotHubRouteAndEndpoint removes the named route and custom endpoint
+// from the IoT Hub's routing configuration. Callers must hold
+// locks.ByName(iotHubId.Name, iotHubResourceName) before calling this.
+func removeIotHubRouteAndEndpoint(ctx context.Context, client *devices.IotHubResourceClient, iotHubId parse.IotHubId, routeName string, endpointName string) error {
+	hub, err := client.Get(ctx, iotHubId.ResourceGroup, iotHubId.Name)
+	if err != nil {
+		return fmt.Errorf("retrieving IoT Hub %q (Resource Group %q): %+v", iotHubId.Name, iotHubId.ResourceGroup, err)
+	}
+	if hub.Properties == nil || hub.Properties.Routing == nil {
+		return nil
+	}
+
+	if hub.Properties.Routing.Routes != nil {
+		remainingRoutes := make([]devices.RouteProperties, 0)
+		for _, route := range *hub.Properties.Routing.Routes {
+			if route.Name == nil || *route.Name != routeName {
+				remainingRoutes = append(remainingRoutes, route)
+			}
+		}
+		hub.Properties.Routing.Routes = &remainingRoutes
+	}
+
+	if hub.Properties.Routing.Endpoints != nil {
+		removeRoutingEndpoint(hub.Properties.Routing.Endpoints, endpointName)
+	}
+
+	ifMatch := ""
+	if hub.Etag != nil {
+		ifMatch = *hub.Etag
+	}
+
+	future, err := client.CreateOrUpdate(ctx, iotHubId.ResourceGroup, iotHubId.Name, hub, ifMatch)
+	if err != nil {
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, client.Client)
+}
+
+func routingEndpointNameExists(endpoints *devices.RoutingEndpoints, name string) bool {
+	_, ok := routingEndpointType(endpoints, name)
+	return ok
+}
+
+// routingEndpointType returns the `iothub_endpoint_type` value matching the
+// named custom endpoint, searching every endpoint kind this resource can
+// create.
+func routingEndpointType(endpoints *devices.RoutingEndpoints, name string) (string, bool) {
+	if endpoints == nil {
+		return "", false
+	}
+	if endpoints.EventHubs != nil {
+		for _, e := range *endpoints.EventHubs {
+			if e.Name != nil && *e.Name == name {
+				return "EventHub", true
+			}
+		}
+	}
+	if endpoints.ServiceBusQueues != nil {
+		for _, e := range *endpoints.ServiceBusQueues {
+			if e.Name != nil && *e.Name == name {
+				return "ServiceBusQueue", true
+			}
+		}
+	}
+	if endpoints.ServiceBusTopics != nil {
+		for _, e := range *endpoints.ServiceBusTopics {
+			if e.Name != nil && *e.Name == name {
+				return "ServiceBusTopic", true
+			}
+		}
+	}
+	return "", false
+}
+
+func addRoutingEndpoint(endpoints *devices.RoutingEndpoints, endpointType, name, connectionString string) {
+	switch endpointType {
+	case "EventHub":
+		list := []devices.RoutingEventHubProperties{}
+		if endpoints.EventHubs != nil {
+			list = *endpoints.EventHubs
+		}
+		list = append(list, devices.RoutingEventHubProperties{
+			Name:             utils.String(name),
+			ConnectionString: utils.String(connectionString),
+		})
+		endpoints.EventHubs = &list
+	case "ServiceBusQueue":
+		list := []devices.RoutingServiceBusQueueEndpointProperties{}
+		if endpoints.ServiceBusQueues != nil {
+			list = *endpoints.ServiceBusQueues
+		}
+		list = append(list, devices.RoutingServiceBusQueueEndpointProperties{
+			Name:             utils.String(name),
+			ConnectionString: utils.String(connectionString),
+		})
+		endpoints.ServiceBusQueues = &list
+	case "ServiceBusTopic":
+		list := []devices.RoutingServiceBusTopicEndpointProperties{}
+		if endpoints.ServiceBusTopics != nil {
+			list = *endpoints.ServiceBusTopics
+		}
+		list = append(list, devices.RoutingServiceBusTopicEndpointProperties{
+			Name:             utils.String(name),
+			ConnectionString: utils.String(connectionString),
+		})
+		endpoints.ServiceBusTopics = &list
+	}
+}
+
+func removeRoutingEndpoint(endpoints *devices.RoutingEndpoints, name string) {
+	if endpoints.EventHubs != nil {
+		remaining := make([]devices.RoutingEventHubProperties, 0)
+		for _, e := range *endpoints.EventHubs {
+			if e.Name == nil || *e.Name != name {
+				remaining = append(remaining, e)
+			}
+		}
+		endpoints.EventHubs = &remaining
+	}
+	if endpoints.ServiceBusQueues != nil {
+		remaining := make([]devices.RoutingServiceBusQueueEndpointProperties, 0)
+		for _, e := range *endpoints.ServiceBusQueues {
+			if e.Name == nil || *e.Name != name {
+				remaining = append(remaining, e)
+			}
+		}
+		endpoints.ServiceBusQueues = &remaining
+	}
+	if endpoints.ServiceBusTopics != nil {
+		remaining := make([]devices.RoutingServiceBusTopicEndpointProperties, 0)
+		for _, e := range *endpoints.ServiceBusTopics {
+			if e.Name == nil || *e.Name != name {
+				remaining = append(remaining, e)
+			}
+		}
+		endpoints.ServiceBusTopics = &remaining
+	}
+}
+
+// findRoutingEndpointName returns the custom endpoint name that the named
+// route forwards into, or "" if no such route exists.
+func findRoutingEndpointName(props *devices.IotHubProperties, routeName string) string {
+	if props == nil || props.Routing == nil || props.Routing.Routes == nil {
+		return ""
+	}
+	for _, route := range *props.Routing.Routes {
+		if route.Name != nil && *route.Name == routeName && route.EndpointNames != nil && len(*route.EndpointNames) > 0 {
+			return (*route.EndpointNames)[0]
+		}
+	}
+	return ""
+}
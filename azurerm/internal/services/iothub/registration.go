@@ -0,0 +1,35 @@
+package iothub
+
+import "github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+
+type Registration struct{}
+
+func (r Registration) Name() string {
+	return "IoT Hub"
+}
+
+func (r Registration) WebsiteCategories() []string {
+	return []string{
+		"IoT Hub",
+	}
+}
+
+func (r Registration) SupportedDataSources() map[string]*schema.Resource {
+	return map[string]*schema.Resource{
+		"azurerm_iothub_digital_twins": dataSourceArmDigitalTwins(),
+	}
+}
+
+func (r Registration) SupportedResources() map[string]*schema.Resource {
+	// NOTE: azurerm_digital_twins_private_endpoint_connection is not yet
+	// implemented - the pinned 2020-10-31 digitaltwins SDK doesn't expose a
+	// PrivateEndpointConnectionsClient, so surfacing it would mean vendoring
+	// a newer API version. Tracked as a follow-up rather than shipped here.
+	return map[string]*schema.Resource{
+		"azurerm_iothub_digital_twins":               resourceArmDigitalTwins(),
+		"azurerm_digital_twins_endpoint_eventhub":    resourceArmDigitalTwinsEndpointEventHub(),
+		"azurerm_digital_twins_endpoint_servicebus":  resourceArmDigitalTwinsEndpointServiceBus(),
+		"azurerm_digital_twins_endpoint_eventgrid":   resourceArmDigitalTwinsEndpointEventGrid(),
+		"azurerm_iothub_digital_twins_route":         resourceArmIotHubDigitalTwinsRoute(),
+	}
+}